@@ -44,14 +44,34 @@ type (
 		Path    string
 		Package pref.FullName
 
+		// Edition is the editions syntax level (e.g. "2023") as found in the
+		// FileDescriptorProto.edition field. It is only meaningful when
+		// Syntax == pref.Editions.
+		Edition string
+
+		// EditionFeatures is the file-level resolved feature-set, i.e. the
+		// edition defaults overridden by any FileOptions.features present
+		// in the raw descriptor. It seeds feature resolution for every
+		// message, field, and extension declared in this file.
+		EditionFeatures EditionFeatures
+
 		Enums      Enums
 		Messages   Messages
 		Extensions Extensions
 		Services   Services
+
+		// Pool interns recurring values (reserved names, JSON names, and the
+		// like) while lazyRawInit unmarshals this file's descriptors. It is
+		// left nil by default, in which case interning is simply skipped;
+		// callers that load many related files may set it to a shared *Pool
+		// before the first lazyRawInit runs to collapse duplicate
+		// allocations across them.
+		Pool *Pool
 	}
 	FileL2 struct {
-		Options func() pref.ProtoMessage
-		Imports FileImports
+		Options   func() pref.ProtoMessage
+		Imports   FileImports
+		Locations SourceLocations
 	}
 )
 
@@ -71,6 +91,7 @@ func (fd *File) Options() pref.ProtoMessage {
 func (fd *File) Path() string                          { return fd.L1.Path }
 func (fd *File) Package() pref.FullName                { return fd.L1.Package }
 func (fd *File) Imports() pref.FileImports             { return &fd.lazyInit().Imports }
+func (fd *File) SourceLocations() pref.SourceLocations { return &fd.lazyInit().Locations }
 func (fd *File) Enums() pref.EnumDescriptors           { return &fd.L1.Enums }
 func (fd *File) Messages() pref.MessageDescriptors     { return &fd.L1.Messages }
 func (fd *File) Extensions() pref.ExtensionDescriptors { return &fd.L1.Extensions }
@@ -89,7 +110,14 @@ func (fd *File) lazyInit() *FileL2 {
 func (fd *File) lazyInitOnce() {
 	fd.mu.Lock()
 	if fd.L2 == nil {
-		fd.lazyRawInit() // recursively initializes all L2 structures
+		// lazyRawInit no longer walks every message, enum, extension, and
+		// service in the file: it only resolves the file-level imports and
+		// options, and records each top-level descriptor's rawBody slice so
+		// that descriptor's own lazyInit can unmarshal just that subtree on
+		// first use; nested descriptors get their rawBody when their
+		// enclosing message's lazyRawInit runs. See (*Message).lazyInit and
+		// its siblings below.
+		fd.lazyRawInit()
 	}
 	atomic.StoreUint32(&fd.once, 1)
 	fd.mu.Unlock()
@@ -108,7 +136,16 @@ type (
 	Enum struct {
 		Base
 		L1 EnumL1
-		L2 *EnumL2 // protected by fileDesc.once
+
+		// rawBody is the subtree of the file's raw descriptor bytes
+		// covering just this enum (its EnumDescriptorProto), captured by
+		// File.lazyRawInit. It lets lazyInit unmarshal this enum's L2
+		// without touching any other descriptor in the file.
+		rawBody []byte
+
+		once uint32     // atomically set if L2 is valid
+		mu   sync.Mutex // protects L2
+		L2   *EnumL2
 	}
 	EnumL1 struct {
 		eagerValues bool // controls whether EnumL2.Values is already populated
@@ -116,7 +153,7 @@ type (
 	EnumL2 struct {
 		Options        func() pref.ProtoMessage
 		Values         EnumValues
-		ReservedNames  Names
+		ReservedNames  Names // decoded into freshly allocated strings; unlike defaultValue.bytes there is no raw buffer aliased here for EnableMutationRacer to cover
 		ReservedRanges EnumRanges
 	}
 
@@ -147,10 +184,21 @@ func (ed *Enum) ReservedRanges() pref.EnumRanges { return &ed.lazyInit().Reserve
 func (ed *Enum) Format(s fmt.State, r rune)      { descfmt.FormatDesc(s, r, ed) }
 func (ed *Enum) ProtoType(pref.EnumDescriptor)   {}
 func (ed *Enum) lazyInit() *EnumL2 {
-	ed.L0.ParentFile.lazyInit() // implicitly initializes L2
+	if atomic.LoadUint32(&ed.once) == 0 {
+		ed.lazyInitOnce()
+	}
 	return ed.L2
 }
 
+func (ed *Enum) lazyInitOnce() {
+	ed.mu.Lock()
+	if ed.L2 == nil {
+		ed.lazyRawInit() // unmarshals ed.rawBody into ed.L2
+	}
+	atomic.StoreUint32(&ed.once, 1)
+	ed.mu.Unlock()
+}
+
 func (ed *EnumValue) Options() pref.ProtoMessage {
 	if f := ed.L1.Options; f != nil {
 		return f()
@@ -165,7 +213,16 @@ type (
 	Message struct {
 		Base
 		L1 MessageL1
-		L2 *MessageL2 // protected by fileDesc.once
+
+		// rawBody is the subtree of the file's raw descriptor bytes
+		// covering just this message (its DescriptorProto), captured by
+		// File.lazyRawInit. It lets lazyInit unmarshal this message's L2
+		// without touching any other descriptor in the file.
+		rawBody []byte
+
+		once uint32     // atomically set if L2 is valid
+		mu   sync.Mutex // protects L2
+		L2   *MessageL2
 	}
 	MessageL1 struct {
 		Enums      Enums
@@ -178,11 +235,20 @@ type (
 		IsMessageSet          bool // promoted from google.protobuf.MessageOptions
 		Fields                Fields
 		Oneofs                Oneofs
-		ReservedNames         Names
+		ReservedNames         Names // decoded into freshly allocated strings; unlike defaultValue.bytes there is no raw buffer aliased here for EnableMutationRacer to cover
 		ReservedRanges        FieldRanges
 		RequiredNumbers       FieldNumbers // must be consistent with Fields.Cardinality
 		ExtensionRanges       FieldRanges
 		ExtensionRangeOptions []func() pref.ProtoMessage // must be same length as ExtensionRanges
+
+		// Features is this message's resolved feature-set under the
+		// editions syntax. It inherits directly from the parent file's
+		// EditionFeatures (not the enclosing message's, for a nested
+		// message) overridden by this message's own MessageOptions.features;
+		// resolving the full file/message/message chain for deeply nested
+		// messages is left to the Builder. It is unused for proto2/proto3
+		// files.
+		Features EditionFeatures
 	}
 
 	Field struct {
@@ -202,6 +268,23 @@ type (
 		ContainingOneof pref.OneofDescriptor // must be consistent with Message.Oneofs.Fields
 		Enum            pref.EnumDescriptor
 		Message         pref.MessageDescriptor
+
+		// enumRef and messageRef, when non-nil, resolve Enum/Message
+		// lazily instead of using the fields above directly. The
+		// incremental loader sets these (see Message.lazyRawInit) so that
+		// resolving one field's type doesn't force the target message's
+		// own L2 to unmarshal ahead of when it's actually used. Literal
+		// (non-lazy) construction, e.g. from protodesc, leaves these nil
+		// and populates Enum/Message directly.
+		enumRef    *lazyEnumRef
+		messageRef *lazyMessageRef
+
+		// Features is the fully resolved set of feature-set values that
+		// apply to this field under the editions syntax (defaults → file
+		// → message → field → extension). It is unused for proto2/proto3
+		// files, where IsPacked, HasPresence, IsDelimited, and EnforceUTF8
+		// branch directly on FileL1.Syntax instead.
+		Features EditionFeatures
 	}
 
 	Oneof struct {
@@ -239,10 +322,21 @@ func (md *Message) Extensions() pref.ExtensionDescriptors { return &md.L1.Extens
 func (md *Message) ProtoType(pref.MessageDescriptor)      {}
 func (md *Message) Format(s fmt.State, r rune)            { descfmt.FormatDesc(s, r, md) }
 func (md *Message) lazyInit() *MessageL2 {
-	md.L0.ParentFile.lazyInit() // implicitly initializes L2
+	if atomic.LoadUint32(&md.once) == 0 {
+		md.lazyInitOnce()
+	}
 	return md.L2
 }
 
+func (md *Message) lazyInitOnce() {
+	md.mu.Lock()
+	if md.L2 == nil {
+		md.lazyRawInit() // unmarshals md.rawBody into md.L2
+	}
+	atomic.StoreUint32(&md.once, 1)
+	md.mu.Unlock()
+}
+
 // IsMessageSet is a pseudo-internal API for checking whether a message
 // should serialize in the proto1 message format.
 //
@@ -264,6 +358,9 @@ func (fd *Field) Kind() pref.Kind               { return fd.L1.Kind }
 func (fd *Field) HasJSONName() bool             { return fd.L1.JSONName.has }
 func (fd *Field) JSONName() string              { return fd.L1.JSONName.get(fd) }
 func (fd *Field) IsPacked() bool {
+	if fd.L0.ParentFile.L1.Syntax == pref.Editions {
+		return fd.L1.Features.IsPacked
+	}
 	if !fd.L1.HasPacked && fd.L0.ParentFile.L1.Syntax != pref.Proto2 && fd.L1.Cardinality == pref.Repeated {
 		switch fd.L1.Kind {
 		case pref.StringKind, pref.BytesKind, pref.MessageKind, pref.GroupKind:
@@ -273,6 +370,43 @@ func (fd *Field) IsPacked() bool {
 	}
 	return fd.L1.IsPacked
 }
+
+// HasPresence reports whether the field distinguishes between an unset and
+// a default value, either because it was declared with explicit presence
+// (proto2, message, oneof, or an editions field with EXPLICIT presence) or
+// because the resolved editions features say so.
+func (fd *Field) HasPresence() bool {
+	if fd.L1.Cardinality == pref.Repeated {
+		return false
+	}
+	if fd.L0.ParentFile.L1.Syntax == pref.Editions {
+		return fd.L1.Features.IsFieldPresence
+	}
+	isMessage := fd.L1.Kind == pref.MessageKind || fd.L1.Kind == pref.GroupKind
+	return fd.L0.ParentFile.L1.Syntax == pref.Proto2 || isMessage || fd.L1.ContainingOneof != nil
+}
+
+// IsDelimited reports whether a message-kind field is encoded using
+// group-like (delimited) encoding rather than the default length-prefixed
+// encoding. It is always true for proto2 group fields.
+func (fd *Field) IsDelimited() bool {
+	if fd.L1.Kind != pref.GroupKind && fd.L1.Kind != pref.MessageKind {
+		return false
+	}
+	if fd.L0.ParentFile.L1.Syntax == pref.Editions {
+		return fd.L1.Features.IsDelimitedEncoded
+	}
+	return fd.L1.Kind == pref.GroupKind
+}
+
+// EnforceUTF8 reports whether a string-kind field must reject byte
+// sequences that are not valid UTF-8.
+func (fd *Field) EnforceUTF8() bool {
+	if fd.L0.ParentFile.L1.Syntax == pref.Editions {
+		return fd.L1.Features.IsUTF8Validated
+	}
+	return fd.L0.ParentFile.L1.Syntax == pref.Proto3
+}
 func (fd *Field) IsExtension() bool { return false }
 func (fd *Field) IsWeak() bool      { return fd.L1.IsWeak }
 func (fd *Field) IsList() bool      { return fd.Cardinality() == pref.Repeated && !fd.IsMap() }
@@ -296,10 +430,24 @@ func (fd *Field) ContainingOneof() pref.OneofDescriptor      { return fd.L1.Cont
 func (fd *Field) ContainingMessage() pref.MessageDescriptor {
 	return fd.L0.Parent.(pref.MessageDescriptor)
 }
-func (fd *Field) Enum() pref.EnumDescriptor       { return fd.L1.Enum }
-func (fd *Field) Message() pref.MessageDescriptor { return fd.L1.Message }
-func (fd *Field) Format(s fmt.State, r rune)      { descfmt.FormatDesc(s, r, fd) }
-func (fd *Field) ProtoType(pref.FieldDescriptor)  {}
+func (fd *Field) Enum() pref.EnumDescriptor {
+	if fd.L1.enumRef != nil {
+		if ed := fd.L1.enumRef.get(); ed != nil {
+			return ed
+		}
+	}
+	return fd.L1.Enum
+}
+func (fd *Field) Message() pref.MessageDescriptor {
+	if fd.L1.messageRef != nil {
+		if md := fd.L1.messageRef.get(); md != nil {
+			return md
+		}
+	}
+	return fd.L1.Message
+}
+func (fd *Field) Format(s fmt.State, r rune)     { descfmt.FormatDesc(s, r, fd) }
+func (fd *Field) ProtoType(pref.FieldDescriptor) {}
 
 func (od *Oneof) Options() pref.ProtoMessage {
 	if f := od.L1.Options; f != nil {
@@ -315,7 +463,16 @@ type (
 	Extension struct {
 		Base
 		L1 ExtensionL1
-		L2 *ExtensionL2 // protected by fileDesc.once
+
+		// rawBody is the subtree of the file's raw descriptor bytes
+		// covering just this extension (its FieldDescriptorProto), captured
+		// by File.lazyRawInit. It lets lazyInit unmarshal this extension's
+		// L2 without touching any other descriptor in the file.
+		rawBody []byte
+
+		once uint32     // atomically set if L2 is valid
+		mu   sync.Mutex // protects L2
+		L2   *ExtensionL2
 	}
 	ExtensionL1 struct {
 		Number   pref.FieldNumber
@@ -330,6 +487,17 @@ type (
 		Default     defaultValue
 		Enum        pref.EnumDescriptor
 		Message     pref.MessageDescriptor
+
+		// enumRef and messageRef mirror FieldL1's fields of the same name;
+		// see the comment there.
+		enumRef    *lazyEnumRef
+		messageRef *lazyMessageRef
+
+		// Features is this extension's resolved feature-set under the
+		// editions syntax: the parent file's EditionFeatures overridden by
+		// this extension's own FieldOptions.features. Unused outside
+		// editions files.
+		Features EditionFeatures
 	}
 )
 
@@ -339,12 +507,17 @@ func (xd *Extension) Options() pref.ProtoMessage {
 	}
 	return descopts.Field
 }
-func (xd *Extension) Number() pref.FieldNumber                   { return xd.L1.Number }
-func (xd *Extension) Cardinality() pref.Cardinality              { return xd.lazyInit().Cardinality }
-func (xd *Extension) Kind() pref.Kind                            { return xd.L1.Kind }
-func (xd *Extension) HasJSONName() bool                          { return xd.lazyInit().JSONName.has }
-func (xd *Extension) JSONName() string                           { return xd.lazyInit().JSONName.get(xd) }
-func (xd *Extension) IsPacked() bool                             { return xd.lazyInit().IsPacked }
+func (xd *Extension) Number() pref.FieldNumber      { return xd.L1.Number }
+func (xd *Extension) Cardinality() pref.Cardinality { return xd.lazyInit().Cardinality }
+func (xd *Extension) Kind() pref.Kind               { return xd.L1.Kind }
+func (xd *Extension) HasJSONName() bool             { return xd.lazyInit().JSONName.has }
+func (xd *Extension) JSONName() string              { return xd.lazyInit().JSONName.get(xd) }
+func (xd *Extension) IsPacked() bool {
+	if xd.L0.ParentFile.L1.Syntax == pref.Editions {
+		return xd.lazyInit().Features.IsPacked
+	}
+	return xd.lazyInit().IsPacked
+}
 func (xd *Extension) IsExtension() bool                          { return true }
 func (xd *Extension) IsWeak() bool                               { return false }
 func (xd *Extension) IsList() bool                               { return xd.Cardinality() == pref.Repeated }
@@ -356,21 +529,55 @@ func (xd *Extension) Default() pref.Value                        { return xd.laz
 func (xd *Extension) DefaultEnumValue() pref.EnumValueDescriptor { return xd.lazyInit().Default.enum }
 func (xd *Extension) ContainingOneof() pref.OneofDescriptor      { return nil }
 func (xd *Extension) ContainingMessage() pref.MessageDescriptor  { return xd.L1.Extendee }
-func (xd *Extension) Enum() pref.EnumDescriptor                  { return xd.lazyInit().Enum }
-func (xd *Extension) Message() pref.MessageDescriptor            { return xd.lazyInit().Message }
-func (xd *Extension) Format(s fmt.State, r rune)                 { descfmt.FormatDesc(s, r, xd) }
-func (xd *Extension) ProtoType(pref.FieldDescriptor)             {}
-func (xd *Extension) ProtoInternal(pragma.DoNotImplement)        {}
+func (xd *Extension) Enum() pref.EnumDescriptor {
+	if r := xd.lazyInit().enumRef; r != nil {
+		if ed := r.get(); ed != nil {
+			return ed
+		}
+	}
+	return xd.L2.Enum
+}
+func (xd *Extension) Message() pref.MessageDescriptor {
+	if r := xd.lazyInit().messageRef; r != nil {
+		if md := r.get(); md != nil {
+			return md
+		}
+	}
+	return xd.L2.Message
+}
+func (xd *Extension) Format(s fmt.State, r rune)          { descfmt.FormatDesc(s, r, xd) }
+func (xd *Extension) ProtoType(pref.FieldDescriptor)      {}
+func (xd *Extension) ProtoInternal(pragma.DoNotImplement) {}
 func (xd *Extension) lazyInit() *ExtensionL2 {
-	xd.L0.ParentFile.lazyInit() // implicitly initializes L2
+	if atomic.LoadUint32(&xd.once) == 0 {
+		xd.lazyInitOnce()
+	}
 	return xd.L2
 }
 
+func (xd *Extension) lazyInitOnce() {
+	xd.mu.Lock()
+	if xd.L2 == nil {
+		xd.lazyRawInit() // unmarshals xd.rawBody into xd.L2
+	}
+	atomic.StoreUint32(&xd.once, 1)
+	xd.mu.Unlock()
+}
+
 type (
 	Service struct {
 		Base
 		L1 ServiceL1
-		L2 *ServiceL2 // protected by fileDesc.once
+
+		// rawBody is the subtree of the file's raw descriptor bytes
+		// covering just this service (its ServiceDescriptorProto),
+		// captured by File.lazyRawInit. It lets lazyInit unmarshal this
+		// service's L2 without touching any other descriptor in the file.
+		rawBody []byte
+
+		once uint32     // atomically set if L2 is valid
+		mu   sync.Mutex // protects L2
+		L2   *ServiceL2
 	}
 	ServiceL1 struct{}
 	ServiceL2 struct {
@@ -402,10 +609,21 @@ func (sd *Service) Format(s fmt.State, r rune)          { descfmt.FormatDesc(s,
 func (sd *Service) ProtoType(pref.ServiceDescriptor)    {}
 func (sd *Service) ProtoInternal(pragma.DoNotImplement) {}
 func (sd *Service) lazyInit() *ServiceL2 {
-	sd.L0.ParentFile.lazyInit() // implicitly initializes L2
+	if atomic.LoadUint32(&sd.once) == 0 {
+		sd.lazyInitOnce()
+	}
 	return sd.L2
 }
 
+func (sd *Service) lazyInitOnce() {
+	sd.mu.Lock()
+	if sd.L2 == nil {
+		sd.lazyRawInit() // unmarshals sd.rawBody into sd.L2
+	}
+	atomic.StoreUint32(&sd.once, 1)
+	sd.mu.Unlock()
+}
+
 func (md *Method) Options() pref.ProtoMessage {
 	if f := md.L1.Options; f != nil {
 		return f()
@@ -498,14 +716,24 @@ func unmarshalDefault(b []byte, k pref.Kind, pf *File, ed pref.EnumDescriptor) d
 	if err != nil {
 		panic(err)
 	}
+	if k == pref.BytesKind {
+		// Intern the default through the file's Pool, if it has one, so
+		// that the same repeated `bytes` default recurring across many
+		// fields (a common case for aggregated FileDescriptorSets) shares
+		// one backing array instead of allocating a fresh copy per field.
+		if raw, ok := v.Interface().([]byte); ok {
+			v = pref.ValueOfBytes(pf.L1.Pool.Bytes(raw))
+		}
+	}
 	return DefaultValue(v, ev)
 }
 
 type defaultValue struct {
-	has   bool
-	val   pref.Value
-	enum  pref.EnumValueDescriptor
-	bytes []byte
+	has      bool
+	val      pref.Value
+	enum     pref.EnumValueDescriptor
+	bytes    []byte
+	raceOnce sync.Once
 }
 
 func (dv *defaultValue) get(fd pref.FieldDescriptor) pref.Value {
@@ -538,11 +766,12 @@ func (dv *defaultValue) get(fd pref.FieldDescriptor) pref.Value {
 		}
 	}
 
-	if len(dv.bytes) > 0 && !bytes.Equal(dv.bytes, dv.val.Bytes()) {
-		// TODO: Avoid panic if we're running with the race detector
-		// and instead spawn a goroutine that periodically resets
-		// this value back to the original to induce a race.
-		panic("detected mutation on the default bytes")
+	if len(dv.bytes) > 0 {
+		if mutationRacerEnabled {
+			dv.raceOnce.Do(func() { EnableMutationRacer(dv.val.Bytes()) })
+		} else if !bytes.Equal(dv.bytes, dv.val.Bytes()) {
+			panic("detected mutation on the default bytes")
+		}
 	}
 	return dv.val
-}
\ No newline at end of file
+}
@@ -0,0 +1,244 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package filedesc
+
+import (
+	"sync"
+
+	"google.golang.org/protobuf/encoding/protowire"
+	pref "google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// SourceLocations is an implementation of pref.SourceLocations backed by
+// the raw google.protobuf.SourceCodeInfo message.
+type SourceLocations struct {
+	// List is a list of SourceLocations.
+	// The SourceLocation.Next field is ignored for this list.
+	List []pref.SourceLocation
+
+	// File is the parent file descriptor that this is used for.
+	// It may be unpopulated, in which case this is not fully functional.
+	File *File
+
+	once   sync.Once
+	byPath map[pathKey]int
+}
+
+// Len reports the number of source locations in the file.
+func (p *SourceLocations) Len() int { return len(p.List) }
+
+// Get returns the ith source location in the file.
+func (p *SourceLocations) Get(i int) pref.SourceLocation { return p.lazyInit().List[i] }
+
+func (p *SourceLocations) byKey(k pathKey) pref.SourceLocation {
+	if p == nil {
+		return pref.SourceLocation{}
+	}
+	p.lazyInit()
+	if i, ok := p.byPath[k]; ok {
+		return p.List[i]
+	}
+	return pref.SourceLocation{}
+}
+
+// ByPath returns the SourceLocation for the given path,
+// returning the zero value if not found.
+func (p *SourceLocations) ByPath(path pref.SourcePath) pref.SourceLocation {
+	return p.byKey(newPathKey(path))
+}
+
+// ByDescriptor returns the SourceLocation for the given descriptor,
+// returning the zero value if not found.
+func (p *SourceLocations) ByDescriptor(desc pref.Descriptor) pref.SourceLocation {
+	if p.File == nil || desc == nil || p.File != desc.ParentFile() {
+		return pref.SourceLocation{} // mismatching parent files
+	}
+	return p.ByPath(descriptorPath(desc))
+}
+
+func (p *SourceLocations) lazyInit() *SourceLocations {
+	p.once.Do(func() {
+		if len(p.List) > 0 {
+			p.byPath = make(map[pathKey]int, len(p.List))
+			for i, l := range p.List {
+				// Populate the map using the first location for a given
+				// path, which is the outer-most declaration in a file.
+				if _, ok := p.byPath[newPathKey(l.Path)]; !ok {
+					p.byPath[newPathKey(l.Path)] = i
+				}
+			}
+		}
+	})
+	return p
+}
+
+// pathKey is a comparable representation of protoreflect.SourcePath.
+type pathKey string
+
+func newPathKey(p pref.SourcePath) pathKey {
+	b := make([]byte, 0, 4*len(p))
+	for _, n := range p {
+		b = protowire.AppendVarint(b, uint64(n))
+	}
+	return pathKey(b)
+}
+
+// descriptorPath returns the SourceCodeInfo path for a descriptor, i.e. the
+// sequence of field numbers and indices that navigate from the top-level
+// FileDescriptorProto down to the descriptor, as used by
+// SourceCodeInfo.Location.path. It allows callers to look up the
+// SourceLocation (and hence the leading/trailing comments) for any message,
+// field, enum, or other descriptor without hand-constructing the path.
+func descriptorPath(d pref.Descriptor) pref.SourcePath {
+	var path pref.SourcePath
+	for d != nil {
+		switch d := d.(type) {
+		case *File:
+			reverse(path)
+			return path
+		case *Message:
+			if _, ok := d.Parent().(*File); ok {
+				path = append(path, int32(d.L0.Index), fileMessageField)
+			} else {
+				path = append(path, int32(d.L0.Index), messageMessageField)
+			}
+		case *Enum:
+			if _, ok := d.Parent().(*File); ok {
+				path = append(path, int32(d.L0.Index), fileEnumField)
+			} else {
+				path = append(path, int32(d.L0.Index), messageEnumField)
+			}
+		case *EnumValue:
+			path = append(path, int32(d.L0.Index), enumValueField)
+		case *Field:
+			if _, ok := d.Parent().(*Message); ok {
+				path = append(path, int32(d.L0.Index), messageFieldField)
+			}
+		case *Oneof:
+			path = append(path, int32(d.L0.Index), messageOneofField)
+		case *Extension:
+			if _, ok := d.Parent().(*File); ok {
+				path = append(path, int32(d.L0.Index), fileExtensionField)
+			} else {
+				path = append(path, int32(d.L0.Index), messageExtensionField)
+			}
+		case *Service:
+			path = append(path, int32(d.L0.Index), fileServiceField)
+		case *Method:
+			path = append(path, int32(d.L0.Index), serviceMethodField)
+		default:
+			reverse(path)
+			return path
+		}
+		d = d.Parent()
+	}
+	reverse(path)
+	return path
+}
+
+func reverse(p pref.SourcePath) {
+	for i, j := 0, len(p)-1; i < j; i, j = i+1, j-1 {
+		p[i], p[j] = p[j], p[i]
+	}
+}
+
+// Field numbers used to construct SourceCodeInfo.Location.path values,
+// mirroring the field numbering of descriptor.proto.
+const (
+	fileDependencyField       = 3  // FileDescriptorProto.dependency
+	fileMessageField          = 4  // FileDescriptorProto.message_type
+	fileEnumField             = 5  // FileDescriptorProto.enum_type
+	fileServiceField          = 6  // FileDescriptorProto.service
+	fileExtensionField        = 7  // FileDescriptorProto.extension
+	fileOptionsField          = 8  // FileDescriptorProto.options
+	fileSourceCodeInfoField   = 9  // FileDescriptorProto.source_code_info
+	filePublicDependencyField = 10 // FileDescriptorProto.public_dependency
+	fileWeakDependencyField   = 11 // FileDescriptorProto.weak_dependency
+	messageFieldField         = 2  // DescriptorProto.field
+	messageOneofField         = 8  // DescriptorProto.oneof_decl
+	messageEnumField          = 4  // DescriptorProto.enum_type
+	messageMessageField       = 3  // DescriptorProto.nested_type
+	enumValueField            = 2  // EnumDescriptorProto.value
+	serviceMethodField        = 2  // ServiceDescriptorProto.method
+)
+
+// asSourceLocations decodes the raw bytes of a google.protobuf.SourceCodeInfo
+// message into a list of pref.SourceLocation. It is called from
+// File.lazyRawInit when the raw descriptor carries source_code_info.
+func asSourceLocations(b []byte) []pref.SourceLocation {
+	var locs []pref.SourceLocation
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		b = b[n:]
+		if num != 1 || typ != protowire.BytesType { // SourceCodeInfo.location
+			n := protowire.ConsumeFieldValue(num, typ, b)
+			b = b[n:]
+			continue
+		}
+		v, n := protowire.ConsumeBytes(b)
+		b = b[n:]
+		locs = append(locs, asSourceLocation(v))
+	}
+	return locs
+}
+
+func asSourceLocation(b []byte) pref.SourceLocation {
+	var loc pref.SourceLocation
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		b = b[n:]
+		switch num {
+		case 1: // path
+			if typ == protowire.BytesType {
+				v, n := protowire.ConsumeBytes(b)
+				b = b[n:]
+				loc.Path = append(loc.Path, asInt32s(v)...)
+				continue
+			}
+		case 2: // span
+			if typ == protowire.BytesType {
+				v, n := protowire.ConsumeBytes(b)
+				b = b[n:]
+				loc.Span = append(loc.Span, asInt32s(v)...)
+				continue
+			}
+		case 3: // leading_comments
+			if typ == protowire.BytesType {
+				v, n := protowire.ConsumeBytes(b)
+				b = b[n:]
+				loc.LeadingComments = string(v)
+				continue
+			}
+		case 4: // trailing_comments
+			if typ == protowire.BytesType {
+				v, n := protowire.ConsumeBytes(b)
+				b = b[n:]
+				loc.TrailingComments = string(v)
+				continue
+			}
+		case 6: // leading_detached_comments
+			if typ == protowire.BytesType {
+				v, n := protowire.ConsumeBytes(b)
+				b = b[n:]
+				loc.LeadingDetachedComments = append(loc.LeadingDetachedComments, string(v))
+				continue
+			}
+		}
+		n := protowire.ConsumeFieldValue(num, typ, b)
+		b = b[n:]
+	}
+	return loc
+}
+
+// asInt32s decodes a packed repeated int32 field.
+func asInt32s(b []byte) []int32 {
+	var xs []int32
+	for len(b) > 0 {
+		x, n := protowire.ConsumeVarint(b)
+		b = b[n:]
+		xs = append(xs, int32(x))
+	}
+	return xs
+}
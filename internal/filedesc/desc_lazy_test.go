@@ -0,0 +1,119 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package filedesc
+
+import (
+	"reflect"
+	"testing"
+
+	"google.golang.org/protobuf/encoding/protowire"
+	pref "google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// appendLocation appends one google.protobuf.SourceCodeInfo.Location
+// message (field 1 of SourceCodeInfo) to b.
+func appendLocation(b []byte, path, span []int32, leading, trailing string, detached []string) []byte {
+	var loc []byte
+	if len(path) > 0 {
+		var packed []byte
+		for _, p := range path {
+			packed = protowire.AppendVarint(packed, uint64(p))
+		}
+		loc = protowire.AppendTag(loc, 1, protowire.BytesType)
+		loc = protowire.AppendBytes(loc, packed)
+	}
+	if len(span) > 0 {
+		var packed []byte
+		for _, s := range span {
+			packed = protowire.AppendVarint(packed, uint64(s))
+		}
+		loc = protowire.AppendTag(loc, 2, protowire.BytesType)
+		loc = protowire.AppendBytes(loc, packed)
+	}
+	if leading != "" {
+		loc = protowire.AppendTag(loc, 3, protowire.BytesType)
+		loc = protowire.AppendString(loc, leading)
+	}
+	if trailing != "" {
+		loc = protowire.AppendTag(loc, 4, protowire.BytesType)
+		loc = protowire.AppendString(loc, trailing)
+	}
+	for _, d := range detached {
+		loc = protowire.AppendTag(loc, 6, protowire.BytesType)
+		loc = protowire.AppendString(loc, d)
+	}
+	b = protowire.AppendTag(b, 1, protowire.BytesType)
+	b = protowire.AppendBytes(b, loc)
+	return b
+}
+
+func TestAsSourceLocation(t *testing.T) {
+	var b []byte
+	b = appendLocation(b, []int32{4, 0, 2, 1}, []int32{10, 0, 20}, "leading\n", "trailing\n", []string{"detached one\n", "detached two\n"})
+	locs := asSourceLocations(b)
+	if len(locs) != 1 {
+		t.Fatalf("asSourceLocations returned %d locations, want 1", len(locs))
+	}
+	got := locs[0]
+	want := pref.SourceLocation{
+		Path:                    pref.SourcePath{4, 0, 2, 1},
+		Span:                    []int32{10, 0, 20},
+		LeadingComments:         "leading\n",
+		TrailingComments:        "trailing\n",
+		LeadingDetachedComments: []string{"detached one\n", "detached two\n"},
+	}
+	if !reflect.DeepEqual(got.Path, want.Path) {
+		t.Errorf("Path = %v, want %v", got.Path, want.Path)
+	}
+	if got.LeadingComments != want.LeadingComments {
+		t.Errorf("LeadingComments = %q, want %q", got.LeadingComments, want.LeadingComments)
+	}
+	if got.TrailingComments != want.TrailingComments {
+		t.Errorf("TrailingComments = %q, want %q", got.TrailingComments, want.TrailingComments)
+	}
+	if !reflect.DeepEqual(got.LeadingDetachedComments, want.LeadingDetachedComments) {
+		t.Errorf("LeadingDetachedComments = %v, want %v", got.LeadingDetachedComments, want.LeadingDetachedComments)
+	}
+}
+
+func TestAsSourceLocationsMultiple(t *testing.T) {
+	var b []byte
+	b = appendLocation(b, []int32{4, 0}, []int32{1, 0}, "", "", nil)
+	b = appendLocation(b, []int32{4, 1}, []int32{2, 0}, "", "", nil)
+	locs := asSourceLocations(b)
+	if len(locs) != 2 {
+		t.Fatalf("asSourceLocations returned %d locations, want 2", len(locs))
+	}
+	if got, want := locs[0].Path, (pref.SourcePath{4, 0}); !reflect.DeepEqual(got, want) {
+		t.Errorf("locs[0].Path = %v, want %v", got, want)
+	}
+	if got, want := locs[1].Path, (pref.SourcePath{4, 1}); !reflect.DeepEqual(got, want) {
+		t.Errorf("locs[1].Path = %v, want %v", got, want)
+	}
+}
+
+func TestSourceLocationsByPath(t *testing.T) {
+	locs := &SourceLocations{
+		List: []pref.SourceLocation{
+			{Path: pref.SourcePath{4, 0}, LeadingComments: "first message\n"},
+			{Path: pref.SourcePath{4, 1}, LeadingComments: "second message\n"},
+		},
+	}
+	if got := locs.ByPath(pref.SourcePath{4, 1}); got.LeadingComments != "second message\n" {
+		t.Errorf("ByPath({4,1}).LeadingComments = %q, want %q", got.LeadingComments, "second message\n")
+	}
+	if got := locs.ByPath(pref.SourcePath{4, 99}); got.Path != nil {
+		t.Errorf("ByPath({4,99}) = %+v, want zero value", got)
+	}
+}
+
+func TestNewPathKeyDistinguishesPaths(t *testing.T) {
+	if newPathKey(pref.SourcePath{4, 0}) == newPathKey(pref.SourcePath{4, 1}) {
+		t.Errorf("newPathKey({4,0}) == newPathKey({4,1}), want distinct keys")
+	}
+	if newPathKey(pref.SourcePath{4, 0}) != newPathKey(pref.SourcePath{4, 0}) {
+		t.Errorf("newPathKey({4,0}) != newPathKey({4,0}), want equal keys for equal paths")
+	}
+}
@@ -0,0 +1,701 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package filedesc
+
+import (
+	"strings"
+
+	"google.golang.org/protobuf/encoding/protowire"
+	pref "google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// This file implements the per-descriptor unmarshal path for the
+// incremental loader: File.lazyRawInit slices the raw FileDescriptorProto
+// into per-message/enum/extension/service rawBody subtrees, and each
+// type's own lazyRawInit unmarshals just its subtree, including the
+// fields/values/oneofs/methods it declares.
+//
+// Cross-file type resolution (an import's Messages/Enums, a field whose
+// type_name points outside this file, a method's request/response message
+// declared in another file) is the Builder's job and lives outside this
+// chunk: it requires the symbol table built while resolving a whole
+// FileDescriptorSet, not just one file's raw bytes. Where that matters
+// below, this code does the same-file best-effort lookup (see
+// resolveLocalMessage/resolveLocalEnum) and leaves the rest as an
+// unresolved placeholder (a lazy ref, or a nil descriptor) rather than
+// faking it - Enum()/Message()/Input()/Output() simply come back nil for a
+// cross-file reference until a real Builder replaces these descriptors
+// with fully resolved ones. Likewise, Options() is left unset for every
+// descriptor type except File (see File.lazyRawInit below): turning raw
+// option bytes into a real google.protobuf.*Options message needs the
+// generated descriptorpb type, which this package cannot depend on.
+//
+// What's fully implemented here: name/number/kind/cardinality/json_name
+// for fields, oneof membership, enum values, methods and their streaming
+// flags, reserved names/ranges, same-file default values, and - for
+// editions files - resolving EditionFeatures down the file -> message ->
+// field/extension chain (see editions.go).
+
+// Field numbers used when walking DescriptorProto, EnumDescriptorProto,
+// FieldDescriptorProto, EnumValueDescriptorProto, ServiceDescriptorProto,
+// MethodDescriptorProto, and their *Options sub-messages. See desc_lazy.go
+// for the FileDescriptorProto-level and SourceCodeInfo-level equivalents.
+const (
+	messageOptionsField        = 7  // DescriptorProto.options
+	messageExtensionField      = 6  // DescriptorProto.extension
+	messageExtensionRangeField = 5  // DescriptorProto.extension_range
+	messageReservedRangeField  = 9  // DescriptorProto.reserved_range
+	messageReservedNameField   = 10 // DescriptorProto.reserved_name
+
+	messageOptionsMessageSetField = 1 // MessageOptions.message_set_wire_format
+	messageOptionsMapEntryField   = 7 // MessageOptions.map_entry
+
+	oneofNameField = 1 // OneofDescriptorProto.name
+
+	enumReservedRangeField = 4 // EnumDescriptorProto.reserved_range
+	enumReservedNameField  = 5 // EnumDescriptorProto.reserved_name
+
+	enumValueNameField   = 1 // EnumValueDescriptorProto.name
+	enumValueNumberField = 2 // EnumValueDescriptorProto.number
+
+	fieldNameField         = 1  // FieldDescriptorProto.name
+	fieldNumberField       = 3  // FieldDescriptorProto.number
+	fieldLabelField        = 4  // FieldDescriptorProto.label
+	fieldTypeField         = 5  // FieldDescriptorProto.type
+	fieldTypeNameField     = 6  // FieldDescriptorProto.type_name
+	fieldDefaultValueField = 7  // FieldDescriptorProto.default_value
+	fieldOptionsField      = 8  // FieldDescriptorProto.options
+	fieldOneofIndexField   = 9  // FieldDescriptorProto.oneof_index
+	fieldJSONNameField     = 10 // FieldDescriptorProto.json_name
+
+	fieldOptionsPackedField = 2 // FieldOptions.packed
+
+	methodNameField            = 1 // MethodDescriptorProto.name
+	methodInputTypeField       = 2 // MethodDescriptorProto.input_type
+	methodOutputTypeField      = 3 // MethodDescriptorProto.output_type
+	methodClientStreamingField = 5 // MethodDescriptorProto.client_streaming
+	methodServerStreamingField = 6 // MethodDescriptorProto.server_streaming
+)
+
+// FileOptionsUnmarshaler, when set, lets File.lazyRawInit turn the raw
+// bytes of a FileDescriptorProto's "options" field into a real
+// google.protobuf.FileOptions message. It exists as a hook rather than a
+// direct call to proto.Unmarshal against the generated descriptorpb type
+// because this package is loaded before descriptorpb (which itself
+// depends on protoreflect, and transitively on this package) and so can't
+// import it without a cycle. A Builder that has access to descriptorpb is
+// expected to set this once, before the first file is loaded through the
+// raw-init path; it's left nil by default, in which case File.Options()
+// falls back to descopts.File exactly as it did before this hook existed.
+var FileOptionsUnmarshaler func(raw []byte) pref.ProtoMessage
+
+// lazyRawInit slices fd's raw FileDescriptorProto into per-descriptor
+// rawBody subtrees (consumed by each type's own lazyRawInit) and resolves
+// the file-level pieces that don't belong to any single message, enum,
+// extension, or service: imports, options, and SourceCodeInfo.
+func (fd *File) lazyRawInit() {
+	fd.L2 = &FileL2{}
+	if fd.L1.Syntax == pref.Editions {
+		fd.L1.EditionFeatures = EditionDefaults(fd.L1.Edition)
+	}
+	var msgIdx, enumIdx, extIdx, svcIdx int
+	b := fd.builder.RawDescriptor
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		b = b[n:]
+		switch typ {
+		case protowire.VarintType:
+			v, n := protowire.ConsumeVarint(b)
+			b = b[n:]
+			switch num {
+			case filePublicDependencyField:
+				if int(v) < len(fd.L2.Imports.List) {
+					fd.L2.Imports.List[v].IsPublic = true
+				}
+			case fileWeakDependencyField:
+				if int(v) < len(fd.L2.Imports.List) {
+					fd.L2.Imports.List[v].IsWeak = true
+				}
+			}
+		case protowire.BytesType:
+			v, n := protowire.ConsumeBytes(b)
+			b = b[n:]
+			switch num {
+			case fileDependencyField:
+				// The imported file itself is resolved by the Builder's
+				// symbol table, not here; this placeholder only records
+				// the dependency's path, the same way an unresolved
+				// enumRef/messageRef stands in for a field's type until
+				// first use. Its L2 is pre-set to an empty FileL2 so that
+				// nothing ever triggers this placeholder's own
+				// lazyRawInit (which has no raw descriptor to parse).
+				imp := &File{L1: FileL1{Path: string(v)}, L2: &FileL2{}}
+				fd.L2.Imports.List = append(fd.L2.Imports.List, pref.FileImport{FileDescriptor: imp})
+			case filePublicDependencyField, fileWeakDependencyField:
+				// Some encoders emit these repeated int32 fields packed
+				// (a single length-delimited blob) rather than one
+				// varint-typed tag per value; handle both.
+				for _, idx := range asInt32s(v) {
+					if int(idx) >= len(fd.L2.Imports.List) {
+						continue
+					}
+					if num == filePublicDependencyField {
+						fd.L2.Imports.List[idx].IsPublic = true
+					} else {
+						fd.L2.Imports.List[idx].IsWeak = true
+					}
+				}
+			case fileMessageField:
+				if msgIdx < len(fd.L1.Messages.List) {
+					fd.L1.Messages.List[msgIdx].rawBody = v
+					msgIdx++
+				}
+			case fileEnumField:
+				if enumIdx < len(fd.L1.Enums.List) {
+					fd.L1.Enums.List[enumIdx].rawBody = v
+					enumIdx++
+				}
+			case fileServiceField:
+				if svcIdx < len(fd.L1.Services.List) {
+					fd.L1.Services.List[svcIdx].rawBody = v
+					svcIdx++
+				}
+			case fileExtensionField:
+				if extIdx < len(fd.L1.Extensions.List) {
+					fd.L1.Extensions.List[extIdx].rawBody = v
+					extIdx++
+				}
+			case fileSourceCodeInfoField:
+				fd.L2.Locations.List = asSourceLocations(v)
+				fd.L2.Locations.File = fd
+			case fileOptionsField:
+				if FileOptionsUnmarshaler != nil {
+					raw := append([]byte(nil), v...)
+					fd.L2.Options = func() pref.ProtoMessage { return FileOptionsUnmarshaler(raw) }
+				}
+				if fd.L1.Syntax == pref.Editions {
+					if raw := rawFeaturesOverride(v, fileOptionsFeaturesField); raw != nil {
+						fd.L1.EditionFeatures = resolveEditionFeatures(fd.L1.EditionFeatures, raw)
+					}
+				}
+			}
+		default:
+			n := protowire.ConsumeFieldValue(num, typ, b)
+			b = b[n:]
+		}
+	}
+}
+
+// lazyRawInit unmarshals ed.rawBody (an EnumDescriptorProto) into ed.L2.
+func (ed *Enum) lazyRawInit() {
+	ed.L2 = &EnumL2{}
+	b := ed.rawBody
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		b = b[n:]
+		if typ != protowire.BytesType {
+			n := protowire.ConsumeFieldValue(num, typ, b)
+			b = b[n:]
+			continue
+		}
+		v, n := protowire.ConsumeBytes(b)
+		b = b[n:]
+		switch num {
+		case enumValueField:
+			ed.L2.Values.List = append(ed.L2.Values.List, parseEnumValue(ed, len(ed.L2.Values.List), v))
+		case enumReservedRangeField:
+			start, end := asRangePair(v)
+			ed.L2.ReservedRanges.List = append(ed.L2.ReservedRanges.List, [2]pref.EnumNumber{pref.EnumNumber(start), pref.EnumNumber(end)})
+		case enumReservedNameField:
+			ed.L2.ReservedNames.List = append(ed.L2.ReservedNames.List, ed.L0.ParentFile.L1.Pool.Name(string(v)))
+		}
+	}
+}
+
+// parseEnumValue parses a single EnumValueDescriptorProto (v) declared by
+// ed. Its full name lives in ed's enclosing scope rather than under ed
+// itself: enum constants are flat within their parent the same way C enum
+// members are, so a top-level enum's values sit directly in the file's
+// package, not under the enum's own name.
+func parseEnumValue(ed *Enum, index int, v []byte) EnumValue {
+	var val EnumValue
+	val.L0.ParentFile = ed.L0.ParentFile
+	val.L0.Parent = ed
+	val.L0.Index = index
+	scope := ed.L0.ParentFile.FullName()
+	if ed.L0.Parent != nil {
+		scope = ed.L0.Parent.FullName()
+	}
+	b := v
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		b = b[n:]
+		switch typ {
+		case protowire.VarintType:
+			v, n := protowire.ConsumeVarint(b)
+			b = b[n:]
+			if num == enumValueNumberField {
+				val.L1.Number = pref.EnumNumber(int32(v))
+			}
+		case protowire.BytesType:
+			v, n := protowire.ConsumeBytes(b)
+			b = b[n:]
+			if num == enumValueNameField {
+				val.L0.FullName = ed.L0.ParentFile.L1.Pool.FullName(string(scope) + "." + string(v))
+			}
+		default:
+			n := protowire.ConsumeFieldValue(num, typ, b)
+			b = b[n:]
+		}
+	}
+	return val
+}
+
+// lazyRawInit unmarshals md.rawBody (a DescriptorProto) into md.L2, and
+// threads the raw bytes of each nested message/enum/extension down into
+// their own rawBody so their lazyInit can run independently later.
+func (md *Message) lazyRawInit() {
+	md.L2 = &MessageL2{}
+	if md.L0.ParentFile.L1.Syntax == pref.Editions {
+		md.L2.Features = md.L0.ParentFile.L1.EditionFeatures
+		// Fold in MessageOptions.features before any field is parsed: the
+		// options (field 7) follow the fields (field 2) on the wire, and
+		// parseField seeds each field's Features from md.L2.Features.
+		if opts := rawFeaturesOverride(md.rawBody, messageOptionsField); opts != nil {
+			if raw := rawFeaturesOverride(opts, messageOptionsFeaturesField); raw != nil {
+				md.L2.Features = resolveEditionFeatures(md.L2.Features, raw)
+			}
+		}
+	}
+	var nestedIdx, enumIdx, extIdx int
+	var oneofIdxs []int32 // parallel to md.L2.Fields.List; -1 if the field isn't in a oneof
+	b := md.rawBody
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		b = b[n:]
+		if typ != protowire.BytesType {
+			n := protowire.ConsumeFieldValue(num, typ, b)
+			b = b[n:]
+			continue
+		}
+		v, n := protowire.ConsumeBytes(b)
+		b = b[n:]
+		switch num {
+		case messageFieldField:
+			f, oneofIdx := parseField(md.L0.ParentFile, md, md.L2.Features, len(md.L2.Fields.List), v)
+			md.L2.Fields.List = append(md.L2.Fields.List, f)
+			oneofIdxs = append(oneofIdxs, oneofIdx)
+		case messageOneofField:
+			var od Oneof
+			od.L0.ParentFile = md.L0.ParentFile
+			od.L0.Parent = md
+			od.L0.Index = len(md.L2.Oneofs.List)
+			od.L0.FullName = md.L0.ParentFile.L1.Pool.FullName(string(md.FullName()) + "." + parseOneofName(v))
+			md.L2.Oneofs.List = append(md.L2.Oneofs.List, od)
+		case messageMessageField:
+			if nestedIdx < len(md.L1.Messages.List) {
+				md.L1.Messages.List[nestedIdx].rawBody = v
+				nestedIdx++
+			}
+		case messageEnumField:
+			if enumIdx < len(md.L1.Enums.List) {
+				md.L1.Enums.List[enumIdx].rawBody = v
+				enumIdx++
+			}
+		case messageExtensionField:
+			if extIdx < len(md.L1.Extensions.List) {
+				md.L1.Extensions.List[extIdx].rawBody = v
+				extIdx++
+			}
+		case messageOptionsField:
+			md.L2.IsMessageSet, md.L2.IsMapEntry = parseMessageOptions(v)
+		case messageReservedRangeField:
+			start, end := asRangePair(v)
+			md.L2.ReservedRanges.List = append(md.L2.ReservedRanges.List, [2]pref.FieldNumber{pref.FieldNumber(start), pref.FieldNumber(end)})
+		case messageReservedNameField:
+			md.L2.ReservedNames.List = append(md.L2.ReservedNames.List, md.L0.ParentFile.L1.Pool.Name(string(v)))
+		case messageExtensionRangeField:
+			start, end := asRangePair(v)
+			md.L2.ExtensionRanges.List = append(md.L2.ExtensionRanges.List, [2]pref.FieldNumber{pref.FieldNumber(start), pref.FieldNumber(end)})
+			md.L2.ExtensionRangeOptions = append(md.L2.ExtensionRangeOptions,
+				md.L0.ParentFile.L1.Pool.ExtensionRangeOptionsFunc(string(v), func() pref.ProtoMessage { return nil }))
+		}
+	}
+	// Wire ContainingOneof <-> Oneofs.Fields using the oneof_index recorded
+	// per field above. This has to happen after the loop above is done
+	// appending to md.L2.Fields.List/Oneofs.List: taking addresses into a
+	// slice that's still growing would leave those pointers dangling once
+	// a later append reallocates it.
+	for i, oneofIdx := range oneofIdxs {
+		if oneofIdx < 0 || int(oneofIdx) >= len(md.L2.Oneofs.List) {
+			continue
+		}
+		od := &md.L2.Oneofs.List[oneofIdx]
+		md.L2.Fields.List[i].L1.ContainingOneof = od
+		od.L1.Fields.List = append(od.L1.Fields.List, &md.L2.Fields.List[i])
+	}
+	// RequiredNumbers collects proto2 "required" fields, and, under
+	// editions, fields whose resolved Features mark them LEGACY_REQUIRED
+	// (editions' stand-in for proto2 required semantics).
+	for i := range md.L2.Fields.List {
+		f := &md.L2.Fields.List[i]
+		required := f.L1.Cardinality == pref.Required
+		if md.L0.ParentFile.L1.Syntax == pref.Editions {
+			required = f.L1.Features.IsLegacyRequired
+		}
+		if required {
+			md.L2.RequiredNumbers.List = append(md.L2.RequiredNumbers.List, f.L1.Number)
+		}
+	}
+}
+
+// parseOneofName extracts the name field of a OneofDescriptorProto.
+func parseOneofName(b []byte) string {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		b = b[n:]
+		if typ != protowire.BytesType {
+			n := protowire.ConsumeFieldValue(num, typ, b)
+			b = b[n:]
+			continue
+		}
+		v, n := protowire.ConsumeBytes(b)
+		b = b[n:]
+		if num == oneofNameField {
+			return string(v)
+		}
+	}
+	return ""
+}
+
+// parseField parses a single FieldDescriptorProto (v) declared by parent
+// (a *Message), returning the constructed Field plus the zero-based
+// oneof_decl index it names, or -1 if it isn't part of a oneof.
+// parentFeatures is the enclosing message's already-resolved
+// EditionFeatures, which seeds this field's own Features before any
+// FieldOptions.features override is folded in. Cross-file Enum/Message
+// references are left as lazy refs resolved on first use; see
+// resolveLocalMessage/resolveLocalEnum for the same-file-only limitation
+// that implies.
+func parseField(pf *File, parent *Message, parentFeatures EditionFeatures, index int, v []byte) (f Field, oneofIndex int32) {
+	oneofIndex = -1
+	f.L0.ParentFile = pf
+	f.L0.Parent = parent
+	f.L0.Index = index
+	f.L1.Features = parentFeatures
+	var typeName string
+	b := v
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		b = b[n:]
+		switch typ {
+		case protowire.VarintType:
+			v, n := protowire.ConsumeVarint(b)
+			b = b[n:]
+			switch num {
+			case fieldNumberField:
+				f.L1.Number = pref.FieldNumber(v)
+			case fieldLabelField:
+				f.L1.Cardinality = pref.Cardinality(v)
+			case fieldTypeField:
+				f.L1.Kind = pref.Kind(v)
+			case fieldOneofIndexField:
+				oneofIndex = int32(v)
+			}
+		case protowire.BytesType:
+			v, n := protowire.ConsumeBytes(b)
+			b = b[n:]
+			switch num {
+			case fieldNameField:
+				f.L0.FullName = pf.L1.Pool.FullName(string(parent.FullName()) + "." + string(v))
+			case fieldTypeNameField:
+				typeName = string(v)
+			case fieldDefaultValueField:
+				f.L1.Default = parseFieldDefault(v, f.L1.Kind, pf, typeName)
+			case fieldOptionsField:
+				f.L1.HasPacked, f.L1.IsPacked = parseFieldOptionsPacked(v)
+				if pf.L1.Syntax == pref.Editions {
+					if raw := rawFeaturesOverride(v, fieldOptionsFeaturesField); raw != nil {
+						f.L1.Features = resolveEditionFeatures(f.L1.Features, raw)
+					}
+				}
+			case fieldJSONNameField:
+				f.L1.JSONName = JSONName(pf.L1.Pool.JSONName(string(f.L0.FullName.Name()), string(v)))
+			}
+		default:
+			n := protowire.ConsumeFieldValue(num, typ, b)
+			b = b[n:]
+		}
+	}
+	f.L1.enumRef, f.L1.messageRef = newTypeRefs(pf, f.L1.Kind, typeName)
+	return f, oneofIndex
+}
+
+// parseFieldDefault resolves a FieldDescriptorProto.default_value (raw) for
+// a field of kind k declared in pf, looking typeName up same-file when k is
+// an enum. It's shared by Field and Extension construction.
+//
+// An explicit default on an enum-kind field needs that enum's values to
+// parse against, which unmarshalDefault can only get by dereferencing a
+// non-nil pref.EnumDescriptor; if the enum lives in another file, same-file
+// resolution can't produce one, so the default is left unset rather than
+// forcing a resolution this chunk can't do (or panicking on a nil
+// interface, as unmarshalDefault would if called with ed == nil).
+func parseFieldDefault(raw []byte, k pref.Kind, pf *File, typeName string) defaultValue {
+	if k != pref.EnumKind {
+		return unmarshalDefault(raw, k, pf, nil)
+	}
+	if typeName == "" {
+		return defaultValue{}
+	}
+	ed := pf.resolveLocalEnum(localTypeName(typeName))
+	if ed == nil {
+		return defaultValue{}
+	}
+	ed.lazyInit() // unmarshalDefault reads a same-file enum's L2.Values directly
+	return unmarshalDefault(raw, k, pf, ed)
+}
+
+// lazyRawInit unmarshals xd.rawBody (a FieldDescriptorProto describing the
+// extension) into xd.L2.
+func (xd *Extension) lazyRawInit() {
+	xd.L2 = &ExtensionL2{}
+	if xd.L0.ParentFile.L1.Syntax == pref.Editions {
+		xd.L2.Features = xd.L0.ParentFile.L1.EditionFeatures
+	}
+	var typeName string
+	b := xd.rawBody
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		b = b[n:]
+		switch typ {
+		case protowire.VarintType:
+			v, n := protowire.ConsumeVarint(b)
+			b = b[n:]
+			if num == fieldLabelField {
+				xd.L2.Cardinality = pref.Cardinality(v)
+			}
+		case protowire.BytesType:
+			v, n := protowire.ConsumeBytes(b)
+			b = b[n:]
+			switch num {
+			case fieldTypeNameField:
+				typeName = string(v)
+			case fieldDefaultValueField:
+				xd.L2.Default = parseFieldDefault(v, xd.L1.Kind, xd.L0.ParentFile, typeName)
+			case fieldOptionsField:
+				_, xd.L2.IsPacked = parseFieldOptionsPacked(v)
+				if xd.L0.ParentFile.L1.Syntax == pref.Editions {
+					if raw := rawFeaturesOverride(v, fieldOptionsFeaturesField); raw != nil {
+						xd.L2.Features = resolveEditionFeatures(xd.L2.Features, raw)
+					}
+				}
+			case fieldJSONNameField:
+				xd.L2.JSONName = JSONName(xd.L0.ParentFile.L1.Pool.JSONName(string(xd.L0.FullName.Name()), string(v)))
+			}
+		default:
+			n := protowire.ConsumeFieldValue(num, typ, b)
+			b = b[n:]
+		}
+	}
+	xd.L2.enumRef, xd.L2.messageRef = newTypeRefs(xd.L0.ParentFile, xd.L1.Kind, typeName)
+}
+
+// newTypeRefs returns lazy enum/message references for a field or
+// extension's type_name, resolved against pf on first use rather than
+// eagerly - see the enumRef/messageRef doc comments on FieldL1/ExtensionL2.
+// At most one of the two returned refs is non-nil, chosen by kind; both are
+// nil for a typeName-less (scalar) field.
+func newTypeRefs(pf *File, kind pref.Kind, typeName string) (enumRef *lazyEnumRef, messageRef *lazyMessageRef) {
+	if typeName == "" {
+		return nil, nil
+	}
+	name := localTypeName(typeName)
+	switch kind {
+	case pref.EnumKind:
+		enumRef = newLazyEnumRef(func() *Enum { return pf.resolveLocalEnum(name) })
+	case pref.MessageKind, pref.GroupKind:
+		messageRef = newLazyMessageRef(func() *Message { return pf.resolveLocalMessage(name) })
+	}
+	return enumRef, messageRef
+}
+
+// lazyRawInit unmarshals sd.rawBody (a ServiceDescriptorProto) into sd.L2.
+func (sd *Service) lazyRawInit() {
+	sd.L2 = &ServiceL2{}
+	b := sd.rawBody
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		b = b[n:]
+		if typ != protowire.BytesType {
+			n := protowire.ConsumeFieldValue(num, typ, b)
+			b = b[n:]
+			continue
+		}
+		v, n := protowire.ConsumeBytes(b)
+		b = b[n:]
+		if num == serviceMethodField {
+			sd.L2.Methods.List = append(sd.L2.Methods.List, parseMethod(sd, len(sd.L2.Methods.List), v))
+		}
+	}
+}
+
+// parseMethod parses a single MethodDescriptorProto (v) declared by sd.
+// Input/Output are resolved same-file only: a method's request/response
+// message is commonly declared in an imported file, and resolving that
+// needs the Builder's cross-file symbol table (see
+// resolveLocalMessage/resolveLocalEnum). When the message isn't found in
+// sd's own file, Input()/Output() simply come back nil rather than a
+// typed-nil *Message wrapped in a non-nil interface.
+func parseMethod(sd *Service, index int, v []byte) Method {
+	var m Method
+	m.L0.ParentFile = sd.L0.ParentFile
+	m.L0.Parent = sd
+	m.L0.Index = index
+	b := v
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		b = b[n:]
+		switch typ {
+		case protowire.VarintType:
+			v, n := protowire.ConsumeVarint(b)
+			b = b[n:]
+			switch num {
+			case methodClientStreamingField:
+				m.L1.IsStreamingClient = protowire.DecodeBool(v)
+			case methodServerStreamingField:
+				m.L1.IsStreamingServer = protowire.DecodeBool(v)
+			}
+		case protowire.BytesType:
+			v, n := protowire.ConsumeBytes(b)
+			b = b[n:]
+			switch num {
+			case methodNameField:
+				m.L0.FullName = sd.L0.ParentFile.L1.Pool.FullName(string(sd.FullName()) + "." + string(v))
+			case methodInputTypeField:
+				if msg := sd.L0.ParentFile.resolveLocalMessage(localTypeName(string(v))); msg != nil {
+					m.L1.Input = msg
+				}
+			case methodOutputTypeField:
+				if msg := sd.L0.ParentFile.resolveLocalMessage(localTypeName(string(v))); msg != nil {
+					m.L1.Output = msg
+				}
+			}
+		default:
+			n := protowire.ConsumeFieldValue(num, typ, b)
+			b = b[n:]
+		}
+	}
+	return m
+}
+
+// resolveLocalMessage and resolveLocalEnum perform a best-effort,
+// same-file lookup by short (unqualified) name for a field, extension, or
+// method's message/enum type. They intentionally don't handle nested types
+// or cross-file references reached through an import - that requires the
+// Builder's symbol table. Descriptors built through that fuller path set
+// FieldL1.Enum/Message (or ExtensionL2's equivalents) directly instead of
+// going through enumRef/messageRef, so they're unaffected by this
+// limitation.
+func (fd *File) resolveLocalMessage(name pref.Name) *Message {
+	for i := range fd.L1.Messages.List {
+		if fd.L1.Messages.List[i].L0.FullName.Name() == name {
+			return &fd.L1.Messages.List[i]
+		}
+	}
+	return nil
+}
+
+func (fd *File) resolveLocalEnum(name pref.Name) *Enum {
+	for i := range fd.L1.Enums.List {
+		if fd.L1.Enums.List[i].L0.FullName.Name() == name {
+			return &fd.L1.Enums.List[i]
+		}
+	}
+	return nil
+}
+
+// localTypeName strips the leading-dot/package-qualification off a
+// FieldDescriptorProto.type_name value, leaving just the final path
+// component for use with resolveLocalMessage/resolveLocalEnum.
+func localTypeName(s string) pref.Name {
+	s = strings.TrimPrefix(s, ".")
+	if i := strings.LastIndexByte(s, '.'); i >= 0 {
+		s = s[i+1:]
+	}
+	return pref.Name(s)
+}
+
+// asRangePair decodes a {start,end} range message (DescriptorProto's
+// ReservedRange/ExtensionRange, or EnumDescriptorProto's EnumReservedRange)
+// sharing the same field numbering: start = 1, end = 2.
+func asRangePair(b []byte) (start, end int32) {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		b = b[n:]
+		if typ != protowire.VarintType {
+			n := protowire.ConsumeFieldValue(num, typ, b)
+			b = b[n:]
+			continue
+		}
+		v, n := protowire.ConsumeVarint(b)
+		b = b[n:]
+		switch num {
+		case 1:
+			start = int32(v)
+		case 2:
+			end = int32(v)
+		}
+	}
+	return start, end
+}
+
+// parseMessageOptions decodes the message_set_wire_format and map_entry
+// fields of a MessageOptions message.
+func parseMessageOptions(b []byte) (isMessageSet, isMapEntry bool) {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		b = b[n:]
+		if typ != protowire.VarintType {
+			n := protowire.ConsumeFieldValue(num, typ, b)
+			b = b[n:]
+			continue
+		}
+		v, n := protowire.ConsumeVarint(b)
+		b = b[n:]
+		switch num {
+		case messageOptionsMessageSetField:
+			isMessageSet = protowire.DecodeBool(v)
+		case messageOptionsMapEntryField:
+			isMapEntry = protowire.DecodeBool(v)
+		}
+	}
+	return isMessageSet, isMapEntry
+}
+
+// parseFieldOptionsPacked decodes the packed field of a FieldOptions
+// message, reporting both whether it was explicitly set (hasPacked) and,
+// if so, its value (isPacked). Field.IsPacked needs the former to know
+// whether to fall back to the proto2/proto3 implicit-packed default;
+// Extension.IsPacked doesn't distinguish the two today and only uses the
+// latter.
+func parseFieldOptionsPacked(b []byte) (hasPacked, isPacked bool) {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		b = b[n:]
+		if typ != protowire.VarintType {
+			n := protowire.ConsumeFieldValue(num, typ, b)
+			b = b[n:]
+			continue
+		}
+		v, n := protowire.ConsumeVarint(b)
+		b = b[n:]
+		if num == fieldOptionsPackedField {
+			hasPacked = true
+			isPacked = protowire.DecodeBool(v)
+		}
+	}
+	return hasPacked, isPacked
+}
@@ -0,0 +1,200 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package filedesc
+
+import (
+	"testing"
+
+	"google.golang.org/protobuf/encoding/protowire"
+	pref "google.golang.org/protobuf/reflect/protoreflect"
+)
+
+func appendRangePair(b []byte, start, end int32) []byte {
+	b = protowire.AppendTag(b, 1, protowire.VarintType)
+	b = protowire.AppendVarint(b, uint64(start))
+	b = protowire.AppendTag(b, 2, protowire.VarintType)
+	b = protowire.AppendVarint(b, uint64(end))
+	return b
+}
+
+func TestAsRangePair(t *testing.T) {
+	b := appendRangePair(nil, 10, 20)
+	start, end := asRangePair(b)
+	if start != 10 || end != 20 {
+		t.Errorf("asRangePair() = (%d, %d), want (10, 20)", start, end)
+	}
+}
+
+func TestAsRangePairIgnoresUnknownFields(t *testing.T) {
+	b := appendRangePair(nil, 1, 2)
+	b = protowire.AppendTag(b, 3, protowire.BytesType)
+	b = protowire.AppendString(b, "unrelated")
+	start, end := asRangePair(b)
+	if start != 1 || end != 2 {
+		t.Errorf("asRangePair() = (%d, %d), want (1, 2)", start, end)
+	}
+}
+
+func TestParseMessageOptions(t *testing.T) {
+	tests := []struct {
+		name              string
+		messageSet, entry bool
+	}{
+		{"neither set", false, false},
+		{"message_set_wire_format", true, false},
+		{"map_entry", false, true},
+		{"both", true, true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var b []byte
+			if tc.messageSet {
+				b = protowire.AppendTag(b, messageOptionsMessageSetField, protowire.VarintType)
+				b = protowire.AppendVarint(b, 1)
+			}
+			if tc.entry {
+				b = protowire.AppendTag(b, messageOptionsMapEntryField, protowire.VarintType)
+				b = protowire.AppendVarint(b, 1)
+			}
+			gotSet, gotEntry := parseMessageOptions(b)
+			if gotSet != tc.messageSet || gotEntry != tc.entry {
+				t.Errorf("parseMessageOptions() = (%v, %v), want (%v, %v)", gotSet, gotEntry, tc.messageSet, tc.entry)
+			}
+		})
+	}
+}
+
+func TestParseFieldOptionsPacked(t *testing.T) {
+	if has, packed := parseFieldOptionsPacked(nil); has || packed {
+		t.Errorf("parseFieldOptionsPacked(nil) = (%v, %v), want (false, false)", has, packed)
+	}
+
+	var truthy []byte
+	truthy = protowire.AppendTag(truthy, fieldOptionsPackedField, protowire.VarintType)
+	truthy = protowire.AppendVarint(truthy, 1)
+	if has, packed := parseFieldOptionsPacked(truthy); !has || !packed {
+		t.Errorf("parseFieldOptionsPacked(packed=true) = (%v, %v), want (true, true)", has, packed)
+	}
+
+	var falsy []byte
+	falsy = protowire.AppendTag(falsy, fieldOptionsPackedField, protowire.VarintType)
+	falsy = protowire.AppendVarint(falsy, 0)
+	if has, packed := parseFieldOptionsPacked(falsy); !has || packed {
+		t.Errorf("parseFieldOptionsPacked(packed=false) = (%v, %v), want (true, false): explicit false must still report hasPacked", has, packed)
+	}
+}
+
+func TestLocalTypeName(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{".foo.bar.Baz", "Baz"},
+		{"foo.bar.Baz", "Baz"},
+		{"Baz", "Baz"},
+		{".Baz", "Baz"},
+	}
+	for _, tc := range tests {
+		if got := localTypeName(tc.in); string(got) != tc.want {
+			t.Errorf("localTypeName(%q) = %q, want %q", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestResolveLocalMessageAndEnum(t *testing.T) {
+	fd := &File{}
+	fd.L1.Messages.List = make([]Message, 2)
+	fd.L1.Messages.List[0].L0.FullName = "pkg.Foo"
+	fd.L1.Messages.List[1].L0.FullName = "pkg.Bar"
+	fd.L1.Enums.List = make([]Enum, 1)
+	fd.L1.Enums.List[0].L0.FullName = "pkg.Color"
+
+	if got := fd.resolveLocalMessage("Bar"); got != &fd.L1.Messages.List[1] {
+		t.Errorf("resolveLocalMessage(%q) = %v, want the second message", "Bar", got)
+	}
+	if got := fd.resolveLocalMessage("Missing"); got != nil {
+		t.Errorf("resolveLocalMessage(%q) = %v, want nil", "Missing", got)
+	}
+	if got := fd.resolveLocalEnum("Color"); got != &fd.L1.Enums.List[0] {
+		t.Errorf("resolveLocalEnum(%q) = %v, want the enum", "Color", got)
+	}
+	if got := fd.resolveLocalEnum("Missing"); got != nil {
+		t.Errorf("resolveLocalEnum(%q) = %v, want nil", "Missing", got)
+	}
+}
+
+// TestMessageLazyRawInitReservedAndExtensionRanges builds a DescriptorProto
+// exercising Message.lazyRawInit's reserved-range and extension-range
+// paths together, the way a real message declaring both would.
+func TestMessageLazyRawInitReservedAndExtensionRanges(t *testing.T) {
+	var b []byte
+	b = protowire.AppendTag(b, messageReservedRangeField, protowire.BytesType)
+	b = protowire.AppendBytes(b, appendRangePair(nil, 5, 10))
+	b = protowire.AppendTag(b, messageExtensionRangeField, protowire.BytesType)
+	b = protowire.AppendBytes(b, appendRangePair(nil, 100, 200))
+
+	fd := &File{}
+	md := &Message{}
+	md.L0.ParentFile = fd
+	md.L0.FullName = "pkg.Msg"
+	md.rawBody = b
+	md.lazyRawInit()
+
+	if len(md.L2.ReservedRanges.List) != 1 || md.L2.ReservedRanges.List[0] != [2]pref.FieldNumber{5, 10} {
+		t.Errorf("ReservedRanges = %v, want [[5 10]]", md.L2.ReservedRanges.List)
+	}
+	if len(md.L2.ExtensionRanges.List) != 1 || md.L2.ExtensionRanges.List[0] != [2]pref.FieldNumber{100, 200} {
+		t.Errorf("ExtensionRanges = %v, want [[100 200]]", md.L2.ExtensionRanges.List)
+	}
+	if len(md.L2.ExtensionRangeOptions) != 1 {
+		t.Errorf("len(ExtensionRangeOptions) = %d, want 1 (one slot per extension range)", len(md.L2.ExtensionRangeOptions))
+	}
+}
+
+// TestExtensionLazyRawInitDefaultValue exercises the default-value path of
+// Extension.lazyRawInit for a scalar kind, which doesn't need an Enum to
+// resolve against.
+func TestExtensionLazyRawInitDefaultValue(t *testing.T) {
+	var b []byte
+	b = protowire.AppendTag(b, fieldDefaultValueField, protowire.BytesType)
+	b = protowire.AppendString(b, "7")
+
+	fd := &File{}
+	xd := &Extension{}
+	xd.L0.ParentFile = fd
+	xd.L0.FullName = "pkg.ext"
+	xd.L1.Kind = pref.Int32Kind
+	xd.rawBody = b
+	xd.lazyRawInit()
+
+	if !xd.L2.Default.has {
+		t.Fatalf("Default.has = false, want true")
+	}
+	if got := xd.L2.Default.val.Int(); got != 7 {
+		t.Errorf("Default value = %d, want 7", got)
+	}
+}
+
+// TestExtensionLazyRawInitEnumDefaultCrossFileIsSkipped exercises the
+// cross-file guard in parseFieldDefault: an explicit enum default whose
+// enum type isn't declared in the same file can't be resolved by the
+// same-file-only lookup this loader does, so it must be left unset instead
+// of panicking (unmarshalDefault would panic on a nil EnumDescriptor).
+func TestExtensionLazyRawInitEnumDefaultCrossFileIsSkipped(t *testing.T) {
+	var b []byte
+	b = protowire.AppendTag(b, fieldTypeNameField, protowire.BytesType)
+	b = protowire.AppendString(b, ".other.Color")
+	b = protowire.AppendTag(b, fieldDefaultValueField, protowire.BytesType)
+	b = protowire.AppendString(b, "RED")
+
+	fd := &File{} // no enums declared: "other.Color" can't resolve locally
+	xd := &Extension{}
+	xd.L0.ParentFile = fd
+	xd.L0.FullName = "pkg.ext"
+	xd.L1.Kind = pref.EnumKind
+	xd.rawBody = b
+	xd.lazyRawInit()
+
+	if xd.L2.Default.has {
+		t.Errorf("Default.has = true, want false: the enum type isn't resolvable same-file")
+	}
+}
@@ -0,0 +1,147 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package filedesc
+
+import (
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// EditionFeatures is the resolved set of google.protobuf.FeatureSet values
+// that apply to a single descriptor under the editions syntax. Unlike
+// proto2/proto3, where these properties were implied by the file's syntax
+// keyword, editions resolves each of them independently per descriptor by
+// walking the inheritance chain: built-in defaults for the file's edition,
+// overridden by the file's FileOptions.features, overridden by the
+// enclosing message's MessageOptions.features, and finally overridden by
+// the field's own FieldOptions.features.
+type EditionFeatures struct {
+	IsFieldPresence    bool // features.field_presence == EXPLICIT
+	IsLegacyRequired   bool // features.field_presence == LEGACY_REQUIRED
+	IsOpenEnum         bool // features.enum_type == OPEN
+	IsPacked           bool // features.repeated_field_encoding == PACKED
+	IsDelimitedEncoded bool // features.message_encoding == DELIMITED
+	IsUTF8Validated    bool // features.utf8_validation == VERIFY
+	IsJSONCompliant    bool // features.json_format == ALLOW
+}
+
+// Field numbers of google.protobuf.FeatureSet, used to decode the raw
+// FeatureSet bytes embedded in each *Options message without incurring a
+// dependency on the generated descriptorpb package (which itself depends
+// on protoreflect).
+const (
+	featureSetFieldPresence         = 1
+	featureSetEnumType              = 2
+	featureSetRepeatedFieldEncoding = 3
+	featureSetUTF8Validation        = 4
+	featureSetMessageEncoding       = 5
+	featureSetJSONFormat            = 6
+)
+
+// Enum values of the FeatureSet sub-enums referenced above.
+const (
+	fieldPresenceExplicit       = 1
+	fieldPresenceImplicit       = 2
+	fieldPresenceLegacyRequired = 3
+
+	enumTypeOpen = 1
+
+	repeatedFieldEncodingPacked = 1
+
+	utf8ValidationVerify = 2
+
+	messageEncodingDelimited = 2
+
+	jsonFormatAllow = 1
+)
+
+// EditionDefaults returns the baseline EditionFeatures for a given editions
+// string (e.g. "2023"), before any per-file, per-message, or per-field
+// overrides are folded in. Editions unknown to this binary resolve to the
+// zero EditionFeatures, with every feature false.
+func EditionDefaults(edition string) EditionFeatures {
+	switch edition {
+	case "2023":
+		return EditionFeatures{
+			IsFieldPresence: true,
+			IsOpenEnum:      true,
+			IsPacked:        true,
+			IsUTF8Validated: true,
+			IsJSONCompliant: true,
+		}
+	default:
+		return EditionFeatures{}
+	}
+}
+
+// resolveEditionFeatures folds the FeatureSet overrides found in the raw
+// bytes of an *Options message's "features" sub-message on top of the
+// inherited parent features, returning the result. It is called while
+// building FileL1, MessageL2, FieldL1, and ExtensionL2 so that each descriptor ends up
+// with a fully resolved EditionFeatures value, regardless of how many
+// levels in the chain actually set an override.
+func resolveEditionFeatures(parent EditionFeatures, raw []byte) EditionFeatures {
+	f := parent
+	for len(raw) > 0 {
+		num, typ, n := protowire.ConsumeTag(raw)
+		raw = raw[n:]
+		if typ != protowire.VarintType {
+			n := protowire.ConsumeFieldValue(num, typ, raw)
+			raw = raw[n:]
+			continue
+		}
+		v, n := protowire.ConsumeVarint(raw)
+		raw = raw[n:]
+		switch num {
+		case featureSetFieldPresence:
+			f.IsFieldPresence = v == fieldPresenceExplicit
+			f.IsLegacyRequired = v == fieldPresenceLegacyRequired
+		case featureSetEnumType:
+			f.IsOpenEnum = v == enumTypeOpen
+		case featureSetRepeatedFieldEncoding:
+			f.IsPacked = v == repeatedFieldEncodingPacked
+		case featureSetUTF8Validation:
+			f.IsUTF8Validated = v == utf8ValidationVerify
+		case featureSetMessageEncoding:
+			f.IsDelimitedEncoded = v == messageEncodingDelimited
+		case featureSetJSONFormat:
+			f.IsJSONCompliant = v == jsonFormatAllow
+		}
+	}
+	return f
+}
+
+// Field numbers of the "features" sub-message (a FeatureSet) within
+// FileOptions, MessageOptions, and FieldOptions respectively, used to find
+// each level's override while resolving EditionFeatures down the chain
+// defaults -> file -> message/field -> extension.
+const (
+	fileOptionsFeaturesField    = 50
+	messageOptionsFeaturesField = 12
+	fieldOptionsFeaturesField   = 21
+)
+
+// rawFeaturesOverride scans the raw bytes of an *Options message for its
+// "features" sub-message and returns the raw FeatureSet bytes of the last
+// occurrence (singular message fields use last-one-wins semantics). It
+// returns nil if the options don't set features at all, so callers can
+// tell "no override" apart from "override clears everything".
+func rawFeaturesOverride(b []byte, field uint64) []byte {
+	var raw []byte
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		b = b[n:]
+		if typ != protowire.BytesType {
+			n := protowire.ConsumeFieldValue(num, typ, b)
+			b = b[n:]
+			continue
+		}
+		v, n := protowire.ConsumeBytes(b)
+		b = b[n:]
+		if num == field {
+			raw = v
+		}
+	}
+	return raw
+}
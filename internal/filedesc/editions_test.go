@@ -0,0 +1,130 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package filedesc
+
+import (
+	"testing"
+
+	"google.golang.org/protobuf/encoding/protowire"
+	pref "google.golang.org/protobuf/reflect/protoreflect"
+)
+
+func TestEditionDefaults(t *testing.T) {
+	got := EditionDefaults("2023")
+	want := EditionFeatures{
+		IsFieldPresence: true,
+		IsOpenEnum:      true,
+		IsPacked:        true,
+		IsUTF8Validated: true,
+		IsJSONCompliant: true,
+	}
+	if got != want {
+		t.Errorf("EditionDefaults(\"2023\") = %+v, want %+v", got, want)
+	}
+	if got := EditionDefaults("unknown-future-edition"); got != (EditionFeatures{}) {
+		t.Errorf("EditionDefaults(unknown) = %+v, want zero value", got)
+	}
+}
+
+// appendFeatureSetOverride builds the raw bytes of a FeatureSet message
+// with a single field_presence override, the shape resolveEditionFeatures
+// expects to find nested inside an *Options message's "features" field.
+func appendFeatureSetOverride(fieldPresence uint64) []byte {
+	var b []byte
+	b = protowire.AppendTag(b, featureSetFieldPresence, protowire.VarintType)
+	b = protowire.AppendVarint(b, fieldPresence)
+	return b
+}
+
+func TestResolveEditionFeatures(t *testing.T) {
+	parent := EditionDefaults("2023")
+	raw := appendFeatureSetOverride(fieldPresenceLegacyRequired)
+	got := resolveEditionFeatures(parent, raw)
+	if got.IsFieldPresence {
+		t.Errorf("IsFieldPresence = true, want false after a LEGACY_REQUIRED override")
+	}
+	if !got.IsLegacyRequired {
+		t.Errorf("IsLegacyRequired = false, want true after a LEGACY_REQUIRED override")
+	}
+	// Fields the override didn't touch stay inherited from parent.
+	if got.IsOpenEnum != parent.IsOpenEnum {
+		t.Errorf("IsOpenEnum = %v, want inherited value %v", got.IsOpenEnum, parent.IsOpenEnum)
+	}
+}
+
+func TestRawFeaturesOverride(t *testing.T) {
+	features := appendFeatureSetOverride(fieldPresenceLegacyRequired)
+	var options []byte
+	options = protowire.AppendTag(options, messageOptionsFeaturesField, protowire.BytesType)
+	options = protowire.AppendBytes(options, features)
+	if got := rawFeaturesOverride(options, messageOptionsFeaturesField); string(got) != string(features) {
+		t.Errorf("rawFeaturesOverride returned %v, want %v", got, features)
+	}
+	if got := rawFeaturesOverride(options, fieldOptionsFeaturesField); got != nil {
+		t.Errorf("rawFeaturesOverride for an absent field = %v, want nil", got)
+	}
+}
+
+func TestMessageLazyRawInitResolvesEditionFeatures(t *testing.T) {
+	fd := &File{}
+	fd.L1.Syntax = pref.Editions
+	fd.L1.Edition = "2023"
+	fd.L1.EditionFeatures = EditionDefaults(fd.L1.Edition)
+
+	features := appendFeatureSetOverride(fieldPresenceLegacyRequired)
+	var options []byte
+	options = protowire.AppendTag(options, messageOptionsFeaturesField, protowire.BytesType)
+	options = protowire.AppendBytes(options, features)
+	var rawBody []byte
+	rawBody = protowire.AppendTag(rawBody, messageOptionsField, protowire.BytesType)
+	rawBody = protowire.AppendBytes(rawBody, options)
+
+	md := &Message{}
+	md.L0.ParentFile = fd
+	md.rawBody = rawBody
+	md.lazyRawInit()
+
+	if md.L2.Features.IsFieldPresence {
+		t.Errorf("Features.IsFieldPresence = true, want false after MessageOptions.features override")
+	}
+	if !md.L2.Features.IsLegacyRequired {
+		t.Errorf("Features.IsLegacyRequired = false, want true after MessageOptions.features override")
+	}
+	// Untouched fields still carry the file-level defaults through.
+	if !md.L2.Features.IsOpenEnum {
+		t.Errorf("Features.IsOpenEnum = false, want true (inherited from file defaults)")
+	}
+}
+
+func TestExtensionLazyRawInitResolvesEditionFeaturesAndIsPacked(t *testing.T) {
+	fd := &File{}
+	fd.L1.Syntax = pref.Editions
+	fd.L1.Edition = "2023"
+	fd.L1.EditionFeatures = EditionDefaults(fd.L1.Edition)
+	// Override the file default (packed) down to expanded encoding.
+	fd.L1.EditionFeatures.IsPacked = false
+
+	var featureOverride []byte
+	featureOverride = protowire.AppendTag(featureOverride, featureSetRepeatedFieldEncoding, protowire.VarintType)
+	featureOverride = protowire.AppendVarint(featureOverride, repeatedFieldEncodingPacked)
+	var options []byte
+	options = protowire.AppendTag(options, fieldOptionsFeaturesField, protowire.BytesType)
+	options = protowire.AppendBytes(options, featureOverride)
+	var rawBody []byte
+	rawBody = protowire.AppendTag(rawBody, fieldOptionsField, protowire.BytesType)
+	rawBody = protowire.AppendBytes(rawBody, options)
+
+	xd := &Extension{}
+	xd.L0.ParentFile = fd
+	xd.rawBody = rawBody
+	xd.lazyRawInit()
+
+	if !xd.L2.Features.IsPacked {
+		t.Errorf("Features.IsPacked = false, want true after FieldOptions.features override")
+	}
+	if !xd.IsPacked() {
+		t.Errorf("IsPacked() = false, want true: an editions extension must read Features, not the legacy IsPacked bool")
+	}
+}
@@ -0,0 +1,48 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package filedesc
+
+import "sync"
+
+// lazyMessageRef resolves a cross-reference to a message declared elsewhere
+// in the same file (e.g. a field's or an extension's Message()) without
+// forcing that message's own L2 to be
+// unmarshaled up front. Before the incremental loader, simply returning the
+// *Message directly was fine because File.lazyInit had already unmarshaled
+// every descriptor in the file; now a field can be resolved while its
+// target message is still a placeholder that has not yet had lazyRawInit
+// run on it. Calling Message() on the *Message itself remains enough to
+// trigger the one-time resolution - lazyMessageRef only defers deciding
+// which *Message that is.
+type lazyMessageRef struct {
+	once    sync.Once
+	resolve func() *Message
+	target  *Message
+}
+
+func newLazyMessageRef(resolve func() *Message) *lazyMessageRef {
+	return &lazyMessageRef{resolve: resolve}
+}
+
+func (r *lazyMessageRef) get() *Message {
+	r.once.Do(func() { r.target = r.resolve() })
+	return r.target
+}
+
+// lazyEnumRef is the Enum analog of lazyMessageRef.
+type lazyEnumRef struct {
+	once    sync.Once
+	resolve func() *Enum
+	target  *Enum
+}
+
+func newLazyEnumRef(resolve func() *Enum) *lazyEnumRef {
+	return &lazyEnumRef{resolve: resolve}
+}
+
+func (r *lazyEnumRef) get() *Enum {
+	r.once.Do(func() { r.target = r.resolve() })
+	return r.target
+}
@@ -0,0 +1,101 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package filedesc
+
+import (
+	"testing"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// TestLazyMessageRefResolvesOnce checks that a lazyMessageRef only invokes
+// its resolver the first time it is dereferenced, matching the contract
+// that Field.Message relies on: resolving a cross-reference must not
+// repeatedly force-unmarshal the referenced message's L2.
+func TestLazyMessageRefResolvesOnce(t *testing.T) {
+	calls := 0
+	want := &Message{}
+	ref := newLazyMessageRef(func() *Message {
+		calls++
+		return want
+	})
+	for i := 0; i < 3; i++ {
+		if got := ref.get(); got != want {
+			t.Fatalf("get() = %p, want %p", got, want)
+		}
+	}
+	if calls != 1 {
+		t.Fatalf("resolver called %d times, want 1", calls)
+	}
+}
+
+func TestLazyEnumRefResolvesOnce(t *testing.T) {
+	calls := 0
+	want := &Enum{}
+	ref := newLazyEnumRef(func() *Enum {
+		calls++
+		return want
+	})
+	for i := 0; i < 3; i++ {
+		if got := ref.get(); got != want {
+			t.Fatalf("get() = %p, want %p", got, want)
+		}
+	}
+	if calls != 1 {
+		t.Fatalf("resolver called %d times, want 1", calls)
+	}
+}
+
+// reservedNameRawBody returns the raw DescriptorProto bytes for a message
+// declaring a single reserved_name field, used so the benchmark below
+// actually exercises Message.lazyRawInit's protowire parsing rather than
+// reading a pre-populated L2.
+func reservedNameRawBody(name string) []byte {
+	var b []byte
+	b = protowire.AppendTag(b, messageReservedNameField, protowire.BytesType)
+	b = protowire.AppendString(b, name)
+	return b
+}
+
+// BenchmarkMessageLazyInitCold measures unmarshaling md.L2 from rawBody for
+// only the "used" messages out of a much larger file - the case the
+// incremental loader targets: a reflection server or dynamic client that
+// touches a handful of messages out of a 10k+-message aggregated
+// descriptor set. Compare against BenchmarkMessageLazyInitAll, which
+// forces every message's L2, to see the steady-state win.
+func BenchmarkMessageLazyInitCold(b *testing.B) {
+	const total = 10000
+	const used = 100
+	fd := &File{}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		msgs := make([]Message, total)
+		for j := range msgs {
+			msgs[j].L0.ParentFile = fd
+			msgs[j].rawBody = reservedNameRawBody("r")
+		}
+		for j := 0; j < used; j++ {
+			msgs[j].lazyInit()
+		}
+	}
+}
+
+// BenchmarkMessageLazyInitAll is the pre-incremental-loader baseline: every
+// message's L2 gets unmarshaled, even though only `used` are ever read.
+func BenchmarkMessageLazyInitAll(b *testing.B) {
+	const total = 10000
+	fd := &File{}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		msgs := make([]Message, total)
+		for j := range msgs {
+			msgs[j].L0.ParentFile = fd
+			msgs[j].rawBody = reservedNameRawBody("r")
+		}
+		for j := range msgs {
+			msgs[j].lazyInit()
+		}
+	}
+}
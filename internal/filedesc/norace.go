@@ -0,0 +1,15 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !race
+
+package filedesc
+
+// mutationRacerEnabled is false in ordinary builds, where mutation of a
+// descriptor's default bytes is instead caught lazily by the bytes.Equal
+// check in defaultValue.get.
+const mutationRacerEnabled = false
+
+// EnableMutationRacer is a no-op outside of -race builds; see race.go.
+func EnableMutationRacer(b []byte) {}
@@ -0,0 +1,112 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package filedesc
+
+import (
+	"sync"
+
+	pref "google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Pool interns values that recur across many descriptors so that loading a
+// large number of generated files (or a single large FileDescriptorSet)
+// does not allocate a fresh copy of each repeated string or closure.
+//
+// A Pool is entirely optional: FileL1.Pool may be left nil, in which case
+// every file allocates its own values exactly as before (every method on
+// Pool is a no-op passthrough on a nil receiver). When the lazyRawInit of a
+// message, enum, extension, or service runs in a file with FileL1.Pool set,
+// it interns reserved names, full names, JSON names, bytes default values,
+// and extension-range option getters through it, so identical values
+// recurring across the files sharing that Pool end up as one allocation. Callers that load many related files
+// (e.g. from a single FileDescriptorSet) can construct one Pool with
+// NewPool and assign it to each file's FileL1.Pool before the first access
+// that triggers lazyRawInit.
+//
+// Pool is safe for concurrent use by multiple goroutines.
+type Pool struct {
+	names           sync.Map // string -> pref.Name
+	fullNames       sync.Map // string -> pref.FullName
+	jsonNames       sync.Map // string -> string
+	byteSlices      sync.Map // string -> []byte
+	extRangeOptions sync.Map // string -> func() pref.ProtoMessage
+}
+
+// NewPool returns a new, empty Pool.
+func NewPool() *Pool { return new(Pool) }
+
+// Name interns s as a pref.Name, returning the previously interned value if
+// one with the same string contents has already been seen.
+func (p *Pool) Name(s string) pref.Name {
+	if p == nil {
+		return pref.Name(s)
+	}
+	if v, ok := p.names.Load(s); ok {
+		return v.(pref.Name)
+	}
+	v, _ := p.names.LoadOrStore(s, pref.Name(s))
+	return v.(pref.Name)
+}
+
+// FullName interns s as a pref.FullName.
+func (p *Pool) FullName(s string) pref.FullName {
+	if p == nil {
+		return pref.FullName(s)
+	}
+	if v, ok := p.fullNames.Load(s); ok {
+		return v.(pref.FullName)
+	}
+	v, _ := p.fullNames.LoadOrStore(s, pref.FullName(s))
+	return v.(pref.FullName)
+}
+
+// JSONName interns the result of computing the JSON name for a field,
+// keyed by the (declaredName, jsonName) pair rather than declaredName
+// alone: two fields can share a declared name but carry different explicit
+// json_name overrides (or one has an override and another doesn't), and
+// keying on declaredName only would make the second caller silently get
+// back the first caller's unrelated value.
+func (p *Pool) JSONName(declaredName, jsonName string) string {
+	if p == nil {
+		return jsonName
+	}
+	key := declaredName + "\x00" + jsonName
+	if v, ok := p.jsonNames.Load(key); ok {
+		return v.(string)
+	}
+	v, _ := p.jsonNames.LoadOrStore(key, jsonName)
+	return v.(string)
+}
+
+// Bytes interns b, returning a shared slice for byte-identical content
+// (e.g. repeated `bytes` default values across many fields). Callers must
+// treat the returned slice as read-only, since it may be shared.
+func (p *Pool) Bytes(b []byte) []byte {
+	if p == nil || len(b) == 0 {
+		return b
+	}
+	k := string(b) // implicit copy used only as a map key
+	if v, ok := p.byteSlices.Load(k); ok {
+		return v.([]byte)
+	}
+	v, _ := p.byteSlices.LoadOrStore(k, append([]byte(nil), b...))
+	return v.([]byte)
+}
+
+// ExtensionRangeOptionsFunc interns a niladic ExtensionRangeOptions getter
+// under key, so that the many identical closures produced for extension
+// ranges that carry no options collapse to a single allocation. key should
+// identify the serialized options (e.g. the raw option bytes as a string);
+// callers that can't cheaply derive such a key should simply not intern.
+func (p *Pool) ExtensionRangeOptionsFunc(key string, f func() pref.ProtoMessage) func() pref.ProtoMessage {
+	if p == nil || f == nil {
+		return f
+	}
+	if v, ok := p.extRangeOptions.Load(key); ok {
+		return v.(func() pref.ProtoMessage)
+	}
+	v, _ := p.extRangeOptions.LoadOrStore(key, f)
+	return v.(func() pref.ProtoMessage)
+}
@@ -0,0 +1,136 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package filedesc
+
+import (
+	"sync"
+	"testing"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+func TestPoolNilReceiverIsPassthrough(t *testing.T) {
+	var pool *Pool
+	if got, want := pool.Name("Foo"), "Foo"; string(got) != want {
+		t.Errorf("Name() = %q, want %q", got, want)
+	}
+	if got, want := pool.JSONName("foo_bar", "fooBar"), "fooBar"; got != want {
+		t.Errorf("JSONName() = %q, want %q", got, want)
+	}
+	b := []byte("default")
+	if got := pool.Bytes(b); &got[0] != &b[0] {
+		t.Errorf("Bytes() returned a copy, want the same slice back on a nil Pool")
+	}
+}
+
+func TestPoolJSONNameDedupesIdenticalPairs(t *testing.T) {
+	pool := NewPool()
+	first := pool.JSONName("foo_bar", "fooBar")
+	second := pool.JSONName("foo_bar", "fooBar")
+	if second != first {
+		t.Errorf("second JSONName call returned %q, want %q (identical pairs should intern to the same value)", second, first)
+	}
+}
+
+// TestPoolJSONNameDoesNotCollideAcrossOverrides guards against keying the
+// intern cache on declaredName alone: two fields sharing a declared name
+// but carrying different explicit json_name overrides must each get their
+// own value back, not whichever one happened to be interned first.
+func TestPoolJSONNameDoesNotCollideAcrossOverrides(t *testing.T) {
+	pool := NewPool()
+	got1 := pool.JSONName("foo_bar", "fooBar")
+	got2 := pool.JSONName("foo_bar", "customOverride")
+	if got1 != "fooBar" {
+		t.Errorf(`JSONName("foo_bar", "fooBar") = %q, want "fooBar"`, got1)
+	}
+	if got2 != "customOverride" {
+		t.Errorf(`JSONName("foo_bar", "customOverride") = %q, want "customOverride"`, got2)
+	}
+}
+
+func TestPoolBytesSharesBackingArrayForEqualContent(t *testing.T) {
+	pool := NewPool()
+	b1 := []byte("default value")
+	b2 := []byte("default value") // distinct backing array, identical content
+	got1 := pool.Bytes(b1)
+	got2 := pool.Bytes(b2)
+	if &got1[0] != &got2[0] {
+		t.Errorf("Bytes returned distinct backing arrays for identical content, want the same interned slice")
+	}
+}
+
+func TestPoolConcurrentJSONNameConverges(t *testing.T) {
+	const goroutines = 50
+	pool := NewPool()
+	var wg sync.WaitGroup
+	results := make([]string, goroutines)
+	for i := 0; i < goroutines; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i] = pool.JSONName("foo_bar", "fooBar")
+		}(i)
+	}
+	wg.Wait()
+	for i, got := range results {
+		if got != "fooBar" {
+			t.Errorf("goroutine %d saw JSONName = %q, want %q (all callers interning the same pair must converge on one winner)", i, got, "fooBar")
+		}
+	}
+}
+
+// messageReservedNamesRawBody returns the raw DescriptorProto bytes for a
+// message declaring the given reserved names, used below to exercise
+// Message.lazyRawInit's interning through FileL1.Pool.
+func messageReservedNamesRawBody(names ...string) []byte {
+	var b []byte
+	for _, name := range names {
+		b = protowire.AppendTag(b, messageReservedNameField, protowire.BytesType)
+		b = protowire.AppendString(b, name)
+	}
+	return b
+}
+
+// BenchmarkMessageLazyInitReservedNamesNoPool unmarshals many messages that
+// repeat the same handful of reserved names, as a FileDescriptorSet
+// aggregating many generated files tends to (shared conventions like
+// "_unused" or historical field names). Without a Pool, every message
+// allocates its own copy of each reserved name string.
+func BenchmarkMessageLazyInitReservedNamesNoPool(b *testing.B) {
+	const total = 5000
+	fd := &File{}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		msgs := make([]Message, total)
+		for j := range msgs {
+			msgs[j].L0.ParentFile = fd
+			msgs[j].rawBody = messageReservedNamesRawBody("deprecated_field", "_reserved1", "_reserved2")
+		}
+		for j := range msgs {
+			msgs[j].lazyInit()
+		}
+	}
+}
+
+// BenchmarkMessageLazyInitReservedNamesPooled is identical to
+// BenchmarkMessageLazyInitReservedNamesNoPool except FileL1.Pool is set, so
+// the repeated reserved names collapse to one allocation each instead of
+// one per message.
+func BenchmarkMessageLazyInitReservedNamesPooled(b *testing.B) {
+	const total = 5000
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		fd := &File{}
+		fd.L1.Pool = NewPool()
+		msgs := make([]Message, total)
+		for j := range msgs {
+			msgs[j].L0.ParentFile = fd
+			msgs[j].rawBody = messageReservedNamesRawBody("deprecated_field", "_reserved1", "_reserved2")
+		}
+		for j := range msgs {
+			msgs[j].lazyInit()
+		}
+	}
+}
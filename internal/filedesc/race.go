@@ -0,0 +1,42 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build race
+
+package filedesc
+
+import "time"
+
+// mutationRacerEnabled reports whether the race detector is instrumenting
+// this binary. When true, defaultValue.get relies on EnableMutationRacer
+// instead of the bytes.Equal comparison used in ordinary builds.
+const mutationRacerEnabled = true
+
+// mutationRacerPeriod is deliberately coarse: the goroutine only needs to
+// overlap with an errant write often enough for -race to catch it across a
+// handful of test runs, not to detect every mutation immediately.
+const mutationRacerPeriod = 50 * time.Millisecond
+
+// EnableMutationRacer starts a background goroutine that periodically
+// rewrites b's original contents back into its backing array. If any other
+// goroutine concurrently mutates the same backing array - e.g. a caller
+// that kept and wrote through the []byte returned by a prior
+// pref.Value.Bytes() - the race detector reports the conflicting access
+// immediately, rather than this package discovering the corruption (and
+// panicking) only on the next read.
+//
+// Callers protect against starting more than one racer per value with a
+// sync.Once; EnableMutationRacer itself does not deduplicate.
+func EnableMutationRacer(b []byte) {
+	if len(b) == 0 {
+		return
+	}
+	orig := append([]byte(nil), b...)
+	go func() {
+		for {
+			copy(b, orig)
+			time.Sleep(mutationRacerPeriod)
+		}
+	}()
+}
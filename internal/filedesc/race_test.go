@@ -0,0 +1,27 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build race && manual
+
+package filedesc
+
+import "testing"
+
+// TestMutationRacerDetectsConcurrentWrite documents the intended trigger
+// condition: running under -race, a write into the backing array of a
+// value passed to EnableMutationRacer must be reported as a data race.
+// The test deliberately provokes that race, so the race detector aborts
+// the test binary on a pass - there is no well-formed pass/fail result to
+// assert on. It is gated behind the "manual" build tag so a plain
+// `go test -race ./...` never picks it up; run it explicitly with
+// `go test -race -tags=manual -run TestMutationRacerDetectsConcurrentWrite ./internal/filedesc`
+// when investigating a reported mutation race.
+func TestMutationRacerDetectsConcurrentWrite(t *testing.T) {
+	if !mutationRacerEnabled {
+		t.Skip("race detector not enabled")
+	}
+	b := []byte("default")
+	EnableMutationRacer(b)
+	b[0] = 'D' // intentionally races with the background goroutine's copy(b, orig)
+}